@@ -0,0 +1,188 @@
+package httprouter
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/n-r-w/lg"
+	"github.com/n-r-w/nerr"
+)
+
+// AccessLogFormat - формат строки access-лога
+type AccessLogFormat int
+
+const (
+	// AccessLogCombined - Apache Combined Log Format, совместимый с большинством анализаторов логов
+	AccessLogCombined = AccessLogFormat(iota)
+	// AccessLogJSON - один JSON объект на строку, для приема в ELK/Loki и т.п.
+	AccessLogJSON
+)
+
+// AccessLogOption - опция настройки AccessLog middleware
+type AccessLogOption func(*accessLogConfig)
+
+type accessLogConfig struct {
+	format         AccessLogFormat
+	trustedProxies []string
+	sampler        func(r *http.Request, status int) bool
+	fieldExtractor func(r *http.Request) map[string]any
+}
+
+// WithAccessLogFormat - формат строки лога, по умолчанию AccessLogCombined
+func WithAccessLogFormat(format AccessLogFormat) AccessLogOption {
+	return func(c *accessLogConfig) { c.format = format }
+}
+
+// WithTrustedProxies - IP-адреса или CIDR-подсети прокси, которым разрешено подменять
+// реальный адрес клиента через заголовки X-Forwarded-For/X-Real-IP. Без этой опции в лог
+// всегда пишется r.RemoteAddr
+func WithTrustedProxies(proxies ...string) AccessLogOption {
+	return func(c *accessLogConfig) { c.trustedProxies = append(c.trustedProxies, proxies...) }
+}
+
+// WithSampler - хук для прореживания шумных эндпоинтов (например health-check): если он
+// возвращает false, строка лога для этого запроса не пишется
+func WithSampler(fn func(r *http.Request, status int) bool) AccessLogOption {
+	return func(c *accessLogConfig) { c.sampler = fn }
+}
+
+// WithFieldExtractor - хук для добавления дополнительных полей (например tenant/user из
+// контекста) в строку лога. Работает только для формата AccessLogJSON
+func WithFieldExtractor(fn func(r *http.Request) map[string]any) AccessLogOption {
+	return func(c *accessLogConfig) { c.fieldExtractor = fn }
+}
+
+// AccessLog - структурированный access-лог, устанавливается в New по умолчанию вместо прежнего
+// logRequest (который писал только запросы с Warn/Error и в произвольном текстовом формате).
+// Пишет строку на каждый запрос: адрес клиента (с учетом доверенных прокси), метод, путь, query,
+// протокол, статус, размер ответа, длительность в микросекундах, referrer, user-agent и ID запроса
+func (router *RouterData) AccessLog(opts ...AccessLogOption) MiddlewareFunc {
+	cfg := &accessLogConfig{format: AccessLogCombined}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &responseWriterEx{ResponseWriter: w, code: http.StatusOK}
+
+			next.ServeHTTP(rw, r)
+
+			if cfg.sampler != nil && !cfg.sampler(r, rw.code) {
+				return
+			}
+
+			duration := time.Since(start)
+			addr := accessLogRemoteAddr(r, cfg.trustedProxies)
+
+			var fields map[string]any
+			if cfg.fieldExtractor != nil {
+				fields = cfg.fieldExtractor(r)
+			}
+
+			router.writeAccessLog(cfg.format, r, addr, rw, duration, fields)
+		})
+	}
+}
+
+func (router *RouterData) writeAccessLog(format AccessLogFormat, r *http.Request, addr string,
+	rw *responseWriterEx, duration time.Duration, fields map[string]any) {
+	var level lg.Level
+	switch {
+	case rw.code >= http.StatusInternalServerError:
+		level = lg.Error
+	case rw.code >= http.StatusBadRequest:
+		level = lg.Warn
+	default:
+		level = lg.Info
+	}
+
+	if format == AccessLogJSON {
+		entry := map[string]any{
+			"remote_addr": addr,
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"query":       r.URL.RawQuery,
+			"proto":       r.Proto,
+			"status":      rw.code,
+			"bytes":       rw.bytes,
+			"duration_us": duration.Microseconds(),
+			"referer":     r.Referer(),
+			"user_agent":  r.UserAgent(),
+			"request_id":  RequestID(r),
+		}
+		for k, v := range fields {
+			entry[k] = v
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			router.logger.Err(nerr.New(err))
+			return
+		}
+
+		router.logger.Level(level, "%s", data)
+		return
+	}
+
+	// Apache Combined Log Format: %h %l %u [%t] "%r" %>s %b "%{Referer}i" "%{User-agent}i"
+	router.logger.Level(level, `%s - - [%s] "%s %s %s" %d %d "%s" "%s" request_id=%s duration_us=%d`,
+		addr,
+		time.Now().Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method, r.URL.RequestURI(), r.Proto,
+		rw.code, rw.bytes,
+		r.Referer(), r.UserAgent(),
+		RequestID(r), duration.Microseconds())
+}
+
+// accessLogRemoteAddr - реальный адрес клиента с учетом доверенных прокси: если r.RemoteAddr
+// входит в trustedProxies, адрес берется из X-Forwarded-For (первый адрес в списке) или,
+// если его нет, из X-Real-IP. Иначе используется r.RemoteAddr как есть
+func accessLogRemoteAddr(r *http.Request, trustedProxies []string) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !ipTrusted(host, trustedProxies) {
+		return r.RemoteAddr
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		if i := strings.IndexByte(xff, ','); i >= 0 {
+			return strings.TrimSpace(xff[:i])
+		}
+		return strings.TrimSpace(xff)
+	}
+
+	if xri := r.Header.Get("X-Real-IP"); xri != "" {
+		return xri
+	}
+
+	return r.RemoteAddr
+}
+
+func ipTrusted(host string, trusted []string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, t := range trusted {
+		if _, cidr, err := net.ParseCIDR(t); err == nil {
+			if cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if t == host {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package httprouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPTrusted(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		trusted []string
+		want    bool
+	}{
+		{"exact match", "10.0.0.1", []string{"10.0.0.1"}, true},
+		{"no match", "10.0.0.1", []string{"10.0.0.2"}, false},
+		{"cidr match", "10.0.0.5", []string{"10.0.0.0/24"}, true},
+		{"cidr no match", "192.168.1.5", []string{"10.0.0.0/24"}, false},
+		{"empty trusted list", "10.0.0.1", nil, false},
+		{"unparseable host", "not-an-ip", []string{"10.0.0.0/24"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ipTrusted(tt.host, tt.trusted); got != tt.want {
+				t.Errorf("ipTrusted(%q, %v) = %v, want %v", tt.host, tt.trusted, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessLogRemoteAddr(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		xri        string
+		trusted    []string
+		want       string
+	}{
+		{
+			name:       "untrusted proxy - real remote addr used as-is",
+			remoteAddr: "203.0.113.5:1234",
+			xff:        "198.51.100.1",
+			trusted:    nil,
+			want:       "203.0.113.5:1234",
+		},
+		{
+			name:       "trusted proxy - X-Forwarded-For first entry wins",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        "198.51.100.1, 198.51.100.2",
+			trusted:    []string{"10.0.0.0/8"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "trusted proxy - X-Real-IP used when no X-Forwarded-For",
+			remoteAddr: "10.0.0.1:1234",
+			xri:        "198.51.100.9",
+			trusted:    []string{"10.0.0.0/8"},
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "trusted proxy but neither header set - falls back to remote addr",
+			remoteAddr: "10.0.0.1:1234",
+			trusted:    []string{"10.0.0.0/8"},
+			want:       "10.0.0.1:1234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.xri != "" {
+				req.Header.Set("X-Real-IP", tt.xri)
+			}
+
+			if got := accessLogRemoteAddr(req, tt.trusted); got != tt.want {
+				t.Errorf("accessLogRemoteAddr() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
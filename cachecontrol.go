@@ -0,0 +1,150 @@
+package httprouter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CachePolicy - политика кэширования для CacheControl middleware. Нулевые значения директив
+// (MaxAge == nil и т.п.) означают, что директива не добавляется в заголовок
+type CachePolicy struct {
+	Public               bool
+	Private              bool
+	MaxAge               *int // секунды
+	SMaxAge              *int // секунды
+	NoStore              bool
+	MustRevalidate       bool
+	StaleWhileRevalidate *int // секунды
+	Vary                 []string
+
+	// ETag - считать слабый ETag от тела ответа и отвечать 304, когда он совпадает с If-None-Match
+	ETag bool
+	// LastModified - источник времени для заголовка Last-Modified и проверки If-Modified-Since.
+	// Если не задан (или возвращает нулевое время) - заголовок не выставляется
+	LastModified func(r *http.Request) time.Time
+}
+
+// CacheControl - выставляет Cache-Control/Expires/Vary согласно policy. Если policy просит ETag
+// или LastModified, дополнительно проверяет If-None-Match/If-Modified-Since и, если они совпадают
+// с текущим ответом, отвечает 304 Not Modified без тела
+func (router *RouterData) CacheControl(policy CachePolicy) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+
+			if directives := cacheControlDirectives(policy); len(directives) > 0 {
+				header.Set("Cache-Control", strings.Join(directives, ", "))
+			}
+			if policy.MaxAge != nil {
+				expires := time.Now().Add(time.Duration(*policy.MaxAge) * time.Second)
+				header.Set("Expires", expires.UTC().Format(http.TimeFormat))
+			}
+			if len(policy.Vary) > 0 {
+				// Add, а не Set - CORS middleware (cors.go) для не-wildcard origin уже мог
+				// выставить свой "Vary: Origin" раньше в цепочке, затирать его нельзя: иначе
+				// общий/CDN кэш сможет отдать ответ для одного origin другому
+				for _, v := range policy.Vary {
+					header.Add("Vary", v)
+				}
+			}
+
+			if !policy.ETag && policy.LastModified == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rw, ok := w.(*responseWriterEx)
+			if !ok {
+				panic("internal error")
+			}
+
+			if policy.LastModified != nil {
+				if modified := policy.LastModified(r); !modified.IsZero() {
+					header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+
+					if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+						if t, err := http.ParseTime(ims); err == nil && !modified.Truncate(time.Second).After(t) {
+							rw.code = http.StatusNotModified
+							rw.flush()
+							return
+						}
+					}
+				}
+			}
+
+			if !policy.ETag {
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			// включаем буферизацию тела - ETag считается от всего тела ответа, значит его нельзя
+			// отправлять клиенту, пока обработчик не закончит писать
+			rw.buffer = &bytes.Buffer{}
+			next.ServeHTTP(rw, r)
+
+			etag := weakETag(rw.buffer.Bytes())
+			header.Set("ETag", etag)
+
+			if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag) {
+				rw.code = http.StatusNotModified
+				rw.buffer.Reset()
+			}
+
+			rw.flush()
+		})
+	}
+}
+
+func cacheControlDirectives(policy CachePolicy) []string {
+	if policy.NoStore {
+		return []string{"no-store"}
+	}
+
+	var directives []string
+	switch {
+	case policy.Public:
+		directives = append(directives, "public")
+	case policy.Private:
+		directives = append(directives, "private")
+	}
+	if policy.MaxAge != nil {
+		directives = append(directives, fmt.Sprintf("max-age=%d", *policy.MaxAge))
+	}
+	if policy.SMaxAge != nil {
+		directives = append(directives, fmt.Sprintf("s-maxage=%d", *policy.SMaxAge))
+	}
+	if policy.MustRevalidate {
+		directives = append(directives, "must-revalidate")
+	}
+	if policy.StaleWhileRevalidate != nil {
+		directives = append(directives, fmt.Sprintf("stale-while-revalidate=%d", *policy.StaleWhileRevalidate))
+	}
+
+	return directives
+}
+
+// weakETag - слабый ETag: SHA-256 тела ответа, обрезанный до 16 байт, в base64url
+func weakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + base64.URLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches - сравнивает If-None-Match (возможно, список из нескольких тегов через запятую,
+// либо "*") с посчитанным ETag, не делая различия между сильным и слабым вариантом тега
+func etagMatches(header, etag string) bool {
+	normalized := strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == normalized {
+			return true
+		}
+	}
+
+	return false
+}
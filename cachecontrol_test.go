@@ -0,0 +1,148 @@
+package httprouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestCacheControlDirectives(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy CachePolicy
+		want   []string
+	}{
+		{"no-store wins over everything else", CachePolicy{NoStore: true, Public: true}, []string{"no-store"}},
+		{"public with max-age", CachePolicy{Public: true, MaxAge: intPtr(60)}, []string{"public", "max-age=60"}},
+		{"private with must-revalidate", CachePolicy{Private: true, MustRevalidate: true}, []string{"private", "must-revalidate"}},
+		{"s-maxage and stale-while-revalidate", CachePolicy{SMaxAge: intPtr(30), StaleWhileRevalidate: intPtr(10)}, []string{"s-maxage=30", "stale-while-revalidate=10"}},
+		{"empty policy", CachePolicy{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cacheControlDirectives(tt.policy)
+			if len(got) != len(tt.want) {
+				t.Fatalf("cacheControlDirectives() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("cacheControlDirectives()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWeakETagAndMatches(t *testing.T) {
+	etag := weakETag([]byte("hello world"))
+	if etag == "" || etag[:3] != `W/"` {
+		t.Fatalf("weakETag() = %q, want a weak ETag quoted string", etag)
+	}
+
+	if weakETag([]byte("hello world")) != etag {
+		t.Error("weakETag() should be deterministic for the same body")
+	}
+	if weakETag([]byte("different body")) == etag {
+		t.Error("weakETag() should differ for different bodies")
+	}
+
+	if !etagMatches(etag, etag) {
+		t.Error("etagMatches() should match identical weak etags")
+	}
+	if !etagMatches("*", etag) {
+		t.Error("etagMatches() should match a wildcard If-None-Match")
+	}
+	if !etagMatches(`W/"other", `+etag, etag) {
+		t.Error("etagMatches() should match one of several comma-separated etags")
+	}
+	if etagMatches(`W/"completely-different"`, etag) {
+		t.Error("etagMatches() should not match an unrelated etag")
+	}
+}
+
+func TestCacheControlVaryMergesWithExisting(t *testing.T) {
+	router := &RouterData{}
+	policy := CachePolicy{Vary: []string{"Accept-Encoding"}}
+
+	handler := router.CacheControl(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	rw := &responseWriterEx{ResponseWriter: rec, code: http.StatusOK}
+	// симулируем CORS middleware, уже выставивший Vary: Origin раньше в цепочке (см. cors.go)
+	rw.Header().Set("Vary", "Origin")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(rw, req)
+
+	got := rec.Header().Values("Vary")
+	want := map[string]bool{"Origin": true, "Accept-Encoding": true}
+	if len(got) != len(want) {
+		t.Fatalf("Vary header values = %v, want both Origin and Accept-Encoding preserved", got)
+	}
+	for _, v := range got {
+		if !want[v] {
+			t.Errorf("unexpected Vary value %q", v)
+		}
+	}
+}
+
+func TestCacheControlETagReturns304OnMatch(t *testing.T) {
+	router := &RouterData{}
+	policy := CachePolicy{ETag: true}
+
+	handler := router.CacheControl(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("cached body"))
+	}))
+
+	rec := httptest.NewRecorder()
+	rw := &responseWriterEx{ResponseWriter: rec, code: http.StatusOK}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(rw, req)
+
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag header on first response")
+	}
+	if rec.Body.String() != "cached body" {
+		t.Fatalf("first response body = %q, want %q", rec.Body.String(), "cached body")
+	}
+
+	rec2 := httptest.NewRecorder()
+	rw2 := &responseWriterEx{ResponseWriter: rec2, code: http.StatusOK}
+	req2 := httptest.NewRequest(http.MethodGet, "/", nil)
+	req2.Header.Set("If-None-Match", etag)
+	handler.ServeHTTP(rw2, req2)
+
+	if rec2.Code != http.StatusNotModified {
+		t.Errorf("second request status = %d, want %d", rec2.Code, http.StatusNotModified)
+	}
+	if rec2.Body.Len() != 0 {
+		t.Errorf("304 response body should be empty, got %q", rec2.Body.String())
+	}
+}
+
+func TestCacheControlLastModifiedReturns304(t *testing.T) {
+	modified := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	router := &RouterData{}
+	policy := CachePolicy{LastModified: func(r *http.Request) time.Time { return modified }}
+
+	handler := router.CacheControl(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("body"))
+	}))
+
+	rec := httptest.NewRecorder()
+	rw := &responseWriterEx{ResponseWriter: rec, code: http.StatusOK}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+	handler.ServeHTTP(rw, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+}
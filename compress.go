@@ -0,0 +1,158 @@
+package httprouter
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// minCompressSize - ниже этого порога сжатие не дает ощутимого выигрыша, а только тратит CPU
+const minCompressSize = 1024 // 1 КиБ
+
+// compressionTypeNames - имена кодировок в заголовках Content-Encoding/Accept-Encoding
+var compressionTypeNames = map[CompressionType]string{
+	CompressionGzip:    "gzip",
+	CompressionDeflate: "deflate",
+	CompressionBrotli:  "br",
+	CompressionZstd:    "zstd",
+}
+
+// nonCompressibleContentTypePrefixes - типы контента, которые уже сжаты сами по себе
+// (изображения, видео, архивы) - повторное сжатие не экономит трафик, только грузит CPU
+var nonCompressibleContentTypePrefixes = []string{
+	"image/", "video/", "audio/",
+	"application/zip", "application/gzip", "application/x-7z-compressed", "application/x-rar",
+}
+
+func isCompressibleContentType(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	for _, p := range nonCompressibleContentTypePrefixes {
+		if strings.HasPrefix(ct, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// acceptEncodingToken - одна запись заголовка Accept-Encoding с разобранным q-значением
+type acceptEncodingToken struct {
+	name string
+	q    float64
+}
+
+// negotiateEncoding - разбирает Accept-Encoding (включая q-значения, например
+// "gzip;q=0.5, br;q=1.0"), отбрасывает q=0 и выбирает среди supported кодировку, максимально
+// предпочитаемую клиентом. Возвращает CompressionNo, если общего варианта нет
+func negotiateEncoding(header string, supported []CompressionType) CompressionType {
+	if header == "" || len(supported) == 0 {
+		return CompressionNo
+	}
+
+	supportedNames := make(map[string]CompressionType, len(supported))
+	for _, c := range supported {
+		if name, ok := compressionTypeNames[c]; ok {
+			supportedNames[name] = c
+		}
+	}
+
+	best := CompressionNo
+	bestQ := 0.0
+
+	for _, rawToken := range strings.Split(header, ",") {
+		token := parseAcceptEncodingToken(rawToken)
+		if token.q <= 0 {
+			continue
+		}
+
+		c, ok := supportedNames[token.name]
+		if !ok {
+			continue
+		}
+
+		if token.q > bestQ {
+			best, bestQ = c, token.q
+		}
+	}
+
+	return best
+}
+
+func parseAcceptEncodingToken(raw string) acceptEncodingToken {
+	parts := strings.Split(raw, ";")
+	token := acceptEncodingToken{name: strings.TrimSpace(parts[0]), q: 1.0}
+
+	for _, p := range parts[1:] {
+		p = strings.TrimSpace(p)
+		if !strings.HasPrefix(p, "q=") {
+			continue
+		}
+		if q, err := strconv.ParseFloat(strings.TrimPrefix(p, "q="), 64); err == nil {
+			token.q = q
+		}
+	}
+
+	return token
+}
+
+var (
+	gzipWriterPool  = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	flateWriterPool = sync.Pool{New: func() any {
+		w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return w
+	}}
+	brotliWriterPool = sync.Pool{New: func() any {
+		return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression)
+	}}
+	zstdEncoderPool = sync.Pool{New: func() any {
+		enc, _ := zstd.NewWriter(io.Discard)
+		return enc
+	}}
+)
+
+// compressData - сжимает data выбранным алгоритмом, переиспользуя writer через sync.Pool, чтобы
+// не аллоцировать его заново на каждый запрос
+func compressData(compType CompressionType, data []byte) ([]byte, error) {
+	switch compType {
+	case CompressionGzip:
+		return compressWithPool(&gzipWriterPool, data, func(w any, buf *bytes.Buffer) { w.(*gzip.Writer).Reset(buf) })
+	case CompressionDeflate:
+		return compressWithPool(&flateWriterPool, data, func(w any, buf *bytes.Buffer) { w.(*flate.Writer).Reset(buf) })
+	case CompressionBrotli:
+		return compressWithPool(&brotliWriterPool, data, func(w any, buf *bytes.Buffer) { w.(*brotli.Writer).Reset(buf) })
+	case CompressionZstd:
+		return compressWithPool(&zstdEncoderPool, data, func(w any, buf *bytes.Buffer) { w.(*zstd.Encoder).Reset(buf) })
+	default:
+		return data, nil
+	}
+}
+
+// compressWriter - общий интерфейс пишущих в буфер компрессоров из sync.Pool выше
+type compressWriter interface {
+	Write(p []byte) (int, error)
+	Close() error
+}
+
+func compressWithPool(pool *sync.Pool, data []byte, reset func(w any, buf *bytes.Buffer)) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := pool.Get()
+	defer pool.Put(w)
+	reset(w, &buf)
+
+	cw := w.(compressWriter)
+	if _, err := cw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := cw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,37 @@
+package httprouter
+
+import "testing"
+
+func TestNegotiateEncoding(t *testing.T) {
+	supported := []CompressionType{CompressionGzip, CompressionBrotli, CompressionZstd}
+
+	tests := []struct {
+		name   string
+		header string
+		want   CompressionType
+	}{
+		{"empty header", "", CompressionNo},
+		{"single supported", "gzip", CompressionGzip},
+		{"single unsupported", "identity", CompressionNo},
+		{"q values pick the best", "gzip;q=0.5, br;q=1.0", CompressionBrotli},
+		{"q=0 excludes a token", "br;q=0, gzip;q=0.5", CompressionGzip},
+		{"unsupported token ignored", "deflate;q=1.0, zstd;q=0.9", CompressionZstd},
+		{"ties keep the first seen", "gzip;q=0.8, br;q=0.8", CompressionGzip},
+		{"whitespace around tokens", " gzip ; q=0.9 , br ; q=0.2 ", CompressionGzip},
+		{"malformed q falls back to 1.0", "gzip;q=nope", CompressionGzip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiateEncoding(tt.header, supported); got != tt.want {
+				t.Errorf("negotiateEncoding(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiateEncodingNoSupported(t *testing.T) {
+	if got := negotiateEncoding("gzip;q=1.0", nil); got != CompressionNo {
+		t.Errorf("negotiateEncoding with no supported types = %v, want CompressionNo", got)
+	}
+}
@@ -0,0 +1,194 @@
+package httprouter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/n-r-w/nerr"
+)
+
+// CORSConfig - настройки политики CORS для корневого роутера (WithCORS) или отдельного
+// подроутера (AddCORS)
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int // секунды, значение заголовка Access-Control-Max-Age
+	// AllowOriginFunc - если задан, используется вместо AllowedOrigins для проверки Origin
+	AllowOriginFunc func(origin string) bool
+}
+
+// WithCORS - задать политику CORS для корневого роутера вместо используемой по умолчанию
+// разрешающей политики (AllowedOrigins: ["*"], без credentials). Если cfg.AllowCredentials
+// выставлен вместе с AllowedOrigins: ["*"] (браузер все равно откажется слать credentials с таким
+// заголовком, а это почти всегда ошибка конфигурации), New залогирует ошибку и вернет корневой
+// политике значение по умолчанию (AllowedOrigins: ["*"], без credentials) вместо cfg - так же,
+// как AddCORS возвращает ошибку и не применяет некорректный cfg к подроутеру
+func WithCORS(cfg CORSConfig) Option {
+	return func(r *RouterData) {
+		r.corsConfig = cfg
+	}
+}
+
+// AddCORS - задать политику CORS для подроутера (например, более строгую, чем для корня, для
+// "/api"). subroute == "" заменяет политику корневого роутера, заданную New/WithCORS.
+//
+// Политика подроутера не добавляется поверх корневой - она ее полностью заменяет для путей,
+// попадающих под subroute (см. corsDispatch). Навесить отдельный corsMiddleware через
+// router.getSubrouter(subroute).Use(...) не сработает: корневые middleware в gorilla/mux
+// оборачивают обработчик снаружи middleware подроутера, поэтому установленный в New() CORS
+// корня ответил бы на preflight раньше, чем middleware подроутера вообще выполнится
+func (router *RouterData) AddCORS(subroute string, cfg CORSConfig) error {
+	if err := validateCORSConfig(cfg); err != nil {
+		return err
+	}
+
+	if len(subroute) == 0 {
+		router.corsConfig = cfg
+		return nil
+	}
+
+	// регистрируем подроутер и в mux (чтобы на его пути можно было вешать маршруты/middleware
+	// как обычно), и в corsSubrouteConfigs (откуда corsDispatch возьмет политику для пути)
+	router.getSubrouter(subroute)
+	router.corsSubrouteConfigs[subroute] = cfg
+	return nil
+}
+
+// corsDispatch - единственный CORS middleware, ставится на корневой роутер в New(). Сам находит
+// политику наиболее специфичного (по длине префикса) подроутера, под который попадает путь
+// запроса, и применяет только ее - так подроутер полностью переопределяет политику корня для
+// своих путей, а не просто выполняется следом за ней
+func (router *RouterData) corsDispatch() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := router.resolveCORSConfig(r.URL.Path)
+			corsMiddleware(cfg)(next).ServeHTTP(w, r)
+		})
+	}
+}
+
+// resolveCORSConfig - политика наиболее специфичного подроутера, под который попадает path, либо
+// корневая политика (WithCORS/AddCORS("", ...)), если ни один подроутер не подошел
+func (router *RouterData) resolveCORSConfig(path string) CORSConfig {
+	cfg := router.corsConfig
+	bestLen := -1
+
+	for prefix, subCfg := range router.corsSubrouteConfigs {
+		if len(prefix) > bestLen && strings.HasPrefix(path, prefix) {
+			cfg = subCfg
+			bestLen = len(prefix)
+		}
+	}
+
+	return cfg
+}
+
+// validateCORSConfig - см. WithCORS
+func validateCORSConfig(cfg CORSConfig) error {
+	if !cfg.AllowCredentials {
+		return nil
+	}
+
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			return nerr.New("cors: AllowCredentials cannot be combined with a wildcard AllowedOrigins entry")
+		}
+	}
+
+	return nil
+}
+
+// corsMiddleware - сама обрабатывает preflight OPTIONS-запрос с разрешенным Origin и отвечает на
+// него 204, не передавая его дальше по цепочке. Запросы без Origin или с неразрешенным Origin
+// (в т.ч. preflight) передаются дальше без CORS-заголовков
+func corsMiddleware(cfg CORSConfig) MiddlewareFunc {
+	allowAllOrigins := false
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			allowAllOrigins = true
+		}
+	}
+
+	allowedMethods := cfg.AllowedMethods
+	if len(allowedMethods) == 0 {
+		allowedMethods = []string{
+			http.MethodGet, http.MethodHead, http.MethodPost,
+			http.MethodPut, http.MethodPatch, http.MethodDelete,
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !corsOriginAllowed(cfg, allowAllOrigins, origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			if allowAllOrigins && !cfg.AllowCredentials {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+				header.Set("Vary", "Origin")
+			}
+			if cfg.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if len(cfg.ExposedHeaders) > 0 {
+				header.Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				header.Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ", "))
+
+				requestedHeaders := cfg.AllowedHeaders
+				if len(requestedHeaders) == 0 {
+					if h := r.Header.Get("Access-Control-Request-Headers"); h != "" {
+						requestedHeaders = []string{h}
+					}
+				}
+				if len(requestedHeaders) > 0 {
+					header.Set("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+				}
+
+				if cfg.MaxAge > 0 {
+					header.Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+				}
+
+				// preflight - короткий ответ без вызова next, основной запрос последует отдельно
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func corsOriginAllowed(cfg CORSConfig, allowAllOrigins bool, origin string) bool {
+	if cfg.AllowOriginFunc != nil {
+		return cfg.AllowOriginFunc(origin)
+	}
+
+	if allowAllOrigins {
+		return true
+	}
+
+	for _, o := range cfg.AllowedOrigins {
+		if o == origin {
+			return true
+		}
+	}
+
+	return false
+}
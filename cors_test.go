@@ -0,0 +1,104 @@
+package httprouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newCORSTestRouter() *RouterData {
+	return &RouterData{
+		corsConfig:          CORSConfig{AllowedOrigins: []string{"*"}},
+		corsSubrouteConfigs: make(map[string]CORSConfig),
+	}
+}
+
+func TestResolveCORSConfigPrefersMostSpecificSubroute(t *testing.T) {
+	router := newCORSTestRouter()
+	router.corsSubrouteConfigs["/api"] = CORSConfig{AllowedOrigins: []string{"https://api.example.com"}}
+	router.corsSubrouteConfigs["/api/admin"] = CORSConfig{AllowedOrigins: []string{"https://admin.example.com"}}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "*"},
+		{"/api/products", "https://api.example.com"},
+		{"/api/admin/users", "https://admin.example.com"},
+	}
+
+	for _, tt := range tests {
+		cfg := router.resolveCORSConfig(tt.path)
+		if len(cfg.AllowedOrigins) != 1 || cfg.AllowedOrigins[0] != tt.want {
+			t.Errorf("resolveCORSConfig(%q).AllowedOrigins = %v, want [%s]", tt.path, cfg.AllowedOrigins, tt.want)
+		}
+	}
+}
+
+// TestCORSSubroutePolicyReplacesRoot проверяет, что политика подроутера полностью заменяет
+// (а не просто дополняет) политику корня для ее путей - это и есть фикс chunk0-5: раньше
+// разрешающий root CORS отвечал на preflight раньше, чем политика подроутера вообще
+// выполнялась, и строгая политика подроутера ни на что не влияла
+func TestCORSSubroutePolicyReplacesRoot(t *testing.T) {
+	router := newCORSTestRouter()
+	router.corsSubrouteConfigs["/api"] = CORSConfig{AllowedOrigins: []string{"https://allowed.example.com"}}
+
+	handler := router.corsDispatch()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// preflight на подроутере с origin, не входящим в его политику - должен быть отклонен,
+	// несмотря на разрешающую политику корня ("*")
+	req := httptest.NewRequest(http.MethodOptions, "/api/products", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("disallowed origin on subroute got Access-Control-Allow-Origin = %q, want empty", got)
+	}
+
+	// тот же путь с разрешенным для подроутера origin - должен пройти по политике подроутера
+	req = httptest.NewRequest(http.MethodOptions, "/api/products", nil)
+	req.Header.Set("Origin", "https://allowed.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://allowed.example.com" {
+		t.Errorf("allowed origin on subroute got Access-Control-Allow-Origin = %q, want https://allowed.example.com", got)
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("preflight status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	// путь вне подроутера продолжает жить по разрешающей политике корня
+	req = httptest.NewRequest(http.MethodGet, "/public", nil)
+	req.Header.Set("Origin", "https://anything.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("root path got Access-Control-Allow-Origin = %q, want *", got)
+	}
+}
+
+func TestAddCORSRejectsCredentialsWithWildcard(t *testing.T) {
+	router := newCORSTestRouter()
+	router.mux = nil // getSubrouter не нужен - валидация должна завершить работу раньше
+
+	err := router.AddCORS("/api", CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	if err == nil {
+		t.Fatal("AddCORS with AllowCredentials and wildcard origin should return an error")
+	}
+}
+
+func TestValidateCORSConfigCredentialsWithWildcard(t *testing.T) {
+	if err := validateCORSConfig(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}); err == nil {
+		t.Fatal("validateCORSConfig should reject AllowCredentials combined with a wildcard origin")
+	}
+	if err := validateCORSConfig(CORSConfig{AllowedOrigins: []string{"https://example.com"}, AllowCredentials: true}); err != nil {
+		t.Errorf("validateCORSConfig with a specific origin and AllowCredentials should pass, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package httprouter
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/n-r-w/nerr"
+)
+
+const (
+	csrfDefaultCookieName = "csrf_token"
+	csrfDefaultHeaderName = "X-CSRF-Token"
+	csrfDefaultFormField  = "csrf_token"
+	csrfDefaultMaxAge     = 24 * time.Hour
+
+	// Ключ для хранения CSRF токена текущего запроса в контексте
+	ctxKeyCSRFToken = contextKey("httprouter-csrf-token")
+)
+
+var (
+	// ErrCSRFTokenMismatch - в unsafe-запросе отсутствует или не совпадает CSRF токен
+	ErrCSRFTokenMismatch = nerr.New("csrf: token mismatch")
+	// ErrCSRFOriginNotAllowed - заголовок Origin присутствует, но не входит в список разрешенных
+	ErrCSRFOriginNotAllowed = nerr.New("csrf: origin not allowed")
+)
+
+// csrfConfig - настройки CSRF middleware, заполняются через CSRFOption
+type csrfConfig struct {
+	cookieName     string
+	headerName     string
+	formField      string
+	secure         bool
+	maxAge         time.Duration
+	exemptPrefixes []string
+	allowedOrigins []string
+}
+
+// CSRFOption - опция настройки CSRF middleware
+type CSRFOption func(*csrfConfig)
+
+// WithCSRFCookieName - имя cookie для хранения CSRF токена (по умолчанию "csrf_token")
+func WithCSRFCookieName(name string) CSRFOption {
+	return func(c *csrfConfig) { c.cookieName = name }
+}
+
+// WithCSRFFormField - имя поля формы, из которого читается токен, если он не передан в заголовке
+func WithCSRFFormField(name string) CSRFOption {
+	return func(c *csrfConfig) { c.formField = name }
+}
+
+// WithCSRFSecure - выставлять Secure у cookie с токеном (должно быть включено при работе по https)
+func WithCSRFSecure(secure bool) CSRFOption {
+	return func(c *csrfConfig) { c.secure = secure }
+}
+
+// WithCSRFMaxAge - срок жизни токена, по истечении которого cookie перевыпускается заново
+func WithCSRFMaxAge(maxAge time.Duration) CSRFOption {
+	return func(c *csrfConfig) { c.maxAge = maxAge }
+}
+
+// WithCSRFExemptPaths - пути (сверяются по префиксу), для которых проверка CSRF не выполняется,
+// например "/api/webhooks/"
+func WithCSRFExemptPaths(prefixes ...string) CSRFOption {
+	return func(c *csrfConfig) { c.exemptPrefixes = append(c.exemptPrefixes, prefixes...) }
+}
+
+// WithCSRFAllowedOrigins - список разрешенных значений заголовка Origin для unsafe-запросов.
+// Если заголовок Origin присутствует у запроса и не входит в этот список, запрос отклоняется
+// еще до сверки токена. Без этой опции csrfOriginAllowed не пропускает все подряд - она сверяет
+// Origin/Referer с Host запроса (same-origin), так что по умолчанию проверка все равно fail-closed
+func WithCSRFAllowedOrigins(origins ...string) CSRFOption {
+	return func(c *csrfConfig) { c.allowedOrigins = append(c.allowedOrigins, origins...) }
+}
+
+// CSRF - защита от CSRF по схеме double-submit cookie. На safe-запросах (GET/HEAD/OPTIONS)
+// выпускается случайный токен (32 байта, base64), который кладется в cookie (чтобы его мог
+// прочитать JS - HttpOnly не выставляется) и в контекст запроса, откуда его можно достать через
+// CSRFToken и подставить в форму. На unsafe-запросах (POST/PUT/PATCH/DELETE) токен из cookie
+// сверяется с токеном, присланным клиентом в заголовке или поле формы
+func (router *RouterData) CSRF(opts ...CSRFOption) MiddlewareFunc {
+	cfg := &csrfConfig{
+		cookieName: csrfDefaultCookieName,
+		headerName: csrfDefaultHeaderName,
+		formField:  csrfDefaultFormField,
+		maxAge:     csrfDefaultMaxAge,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range cfg.exemptPrefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				token := csrfCookieToken(r, cfg.cookieName)
+				if token == "" {
+					newToken, err := newCSRFToken()
+					if err != nil {
+						router.RespondError(w, http.StatusInternalServerError, nerr.New(err))
+						return
+					}
+					token = newToken
+					setCSRFCookie(w, cfg, token)
+				}
+				r = r.WithContext(context.WithValue(r.Context(), ctxKeyCSRFToken, token))
+
+			default:
+				if !csrfOriginAllowed(r, cfg.allowedOrigins) {
+					router.RespondError(w, http.StatusForbidden, ErrCSRFOriginNotAllowed)
+					return
+				}
+
+				cookieToken := csrfCookieToken(r, cfg.cookieName)
+				supplied := r.Header.Get(cfg.headerName)
+				if supplied == "" {
+					supplied = r.FormValue(cfg.formField)
+				}
+
+				if cookieToken == "" || supplied == "" ||
+					subtle.ConstantTimeCompare([]byte(cookieToken), []byte(supplied)) != 1 {
+					router.RespondError(w, http.StatusForbidden, ErrCSRFTokenMismatch)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSRFToken - вернуть CSRF токен текущего запроса, выставленный middleware CSRF.
+// Используется для того, чтобы прокинуть токен в скрытое поле формы или в заголовок ajax-запроса
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(ctxKeyCSRFToken).(string)
+	return token
+}
+
+func newCSRFToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// setCSRFCookie - выставляет cookie со значением, равным самому токену (без примеси) - JS должен
+// прочитать этот cookie и отправить его значение как есть обратно в заголовке/поле формы, поэтому
+// добавлять в Value что-либо, кроме токена, нельзя. Ротация по истечении срока обеспечивается
+// самим MaxAge cookie - когда браузер его удалит, csrfCookieToken просто не найдет cookie и
+// middleware выпустит новый токен
+func setCSRFCookie(w http.ResponseWriter, cfg *csrfConfig, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     cfg.cookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(cfg.maxAge.Seconds()),
+		HttpOnly: false,
+		Secure:   cfg.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// csrfCookieToken - значение cookie с токеном, либо "" если cookie не установлена
+func csrfCookieToken(r *http.Request, cookieName string) string {
+	cookie, err := r.Cookie(cookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// csrfOriginAllowed - проверить Origin (или, если он не передан, Referer - его присылают не все
+// клиенты, но игнорировать его значит оставить для них проверку открытой) запроса против списка
+// разрешенных. Если список не задан (WithCSRFAllowedOrigins не использовалась), проверка не
+// отключается - вместо этого запрос должен быть same-origin с Host. Если ни Origin, ни Referer
+// не переданы вовсе, полагаемся на проверку самого токена ниже по цепочке
+func csrfOriginAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = refererOrigin(r.Referer())
+	}
+	if origin == "" {
+		return true
+	}
+
+	if len(allowed) == 0 {
+		return sameOrigin(origin, r.Host)
+	}
+
+	for _, a := range allowed {
+		if a == origin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refererOrigin - вытащить "scheme://host" из заголовка Referer, если он разбирается как URL
+func refererOrigin(referer string) string {
+	u, err := url.Parse(referer)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// sameOrigin - сравнить Origin (или выведенный из Referer) с Host текущего запроса
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == host
+}
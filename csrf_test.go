@@ -0,0 +1,94 @@
+package httprouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCSRFCookieToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "abc"})
+
+	if token := csrfCookieToken(req, "csrf_token"); token != "abc" {
+		t.Errorf("csrfCookieToken() = %q, want %q", token, "abc")
+	}
+}
+
+func TestCSRFCookieTokenMissing(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if token := csrfCookieToken(req, "csrf_token"); token != "" {
+		t.Errorf("csrfCookieToken() with no cookie = %q, want empty", token)
+	}
+}
+
+// TestSetCSRFCookieRoundTrip проверяет, что значение cookie, выставленное setCSRFCookie, равно
+// самому токену без примесей - именно это значение документированный flow (JS читает cookie и
+// отправляет ее как есть в заголовке/поле формы) должен суметь сравнить с cookie на unsafe-запросе
+func TestSetCSRFCookieRoundTrip(t *testing.T) {
+	cfg := &csrfConfig{cookieName: "csrf_token", maxAge: csrfDefaultMaxAge}
+	rec := httptest.NewRecorder()
+
+	setCSRFCookie(rec, cfg, "the-token")
+
+	result := rec.Result()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range result.Cookies() {
+		req.AddCookie(c)
+	}
+
+	if token := csrfCookieToken(req, "csrf_token"); token != "the-token" {
+		t.Errorf("csrfCookieToken() after setCSRFCookie() = %q, want %q", token, "the-token")
+	}
+}
+
+func TestCSRFOriginAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		referer string
+		host    string
+		allowed []string
+		want    bool
+	}{
+		{"no origin or referer passes through to token check", "", "", "example.com", nil, true},
+		{"same-origin without allow-list", "https://example.com", "", "example.com", nil, true},
+		{"cross-origin without allow-list fails closed", "https://evil.com", "", "example.com", nil, false},
+		{"cross-origin matching allow-list passes", "https://partner.com", "", "example.com", []string{"https://partner.com"}, true},
+		{"cross-origin not in allow-list fails", "https://evil.com", "", "example.com", []string{"https://partner.com"}, false},
+		{"referer fallback same-origin", "", "https://example.com/page", "example.com", nil, true},
+		{"referer fallback cross-origin fails closed", "", "https://evil.com/page", "example.com", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+
+			if got := csrfOriginAllowed(req, tt.allowed); got != tt.want {
+				t.Errorf("csrfOriginAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCSRFTokenIsUnique(t *testing.T) {
+	a, err := newCSRFToken()
+	if err != nil {
+		t.Fatalf("newCSRFToken() error = %v", err)
+	}
+	b, err := newCSRFToken()
+	if err != nil {
+		t.Fatalf("newCSRFToken() error = %v", err)
+	}
+	if a == b {
+		t.Error("newCSRFToken() returned the same token twice")
+	}
+}
@@ -1,6 +1,9 @@
 package httprouter
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+)
 
 type CompressionType int
 
@@ -8,6 +11,8 @@ const (
 	CompressionNo = CompressionType(iota)
 	CompressionGzip
 	CompressionDeflate
+	CompressionBrotli
+	CompressionZstd
 )
 
 type MiddlewareFunc func(next http.Handler) http.Handler
@@ -20,9 +25,11 @@ type Router interface {
 	RespondData(w http.ResponseWriter, code int, contentType string, data interface{})
 	// RespondCompressed - ответ на запрос
 	// data содержит []byte или указатель на объект. Во втором случае этот объект преобразуется в JSON.
-	// Дополнительно проверяет заголовок запроса на "Accept-Encoding" и решает сжимать ли ответ на самом деле,
-	// т.е. в итоге ответ может быть и без сжатия
-	RespondCompressed(w http.ResponseWriter, r *http.Request, code int, compType CompressionType, contentType string, data interface{})
+	// compTypes - кодировки, которые сервер готов отдать для этого ответа (в порядке предпочтения сервера
+	// роли не играет - конкретная кодировка выбирается на основе q-значений заголовка "Accept-Encoding"
+	// клиента). Если клиент не поддерживает ни одну из compTypes, либо данные меньше 1 КиБ, либо
+	// contentType относится к уже сжатому формату (картинки, видео и т.п.) - ответ отправляется без сжатия
+	RespondCompressed(w http.ResponseWriter, r *http.Request, code int, compTypes []CompressionType, contentType string, data interface{})
 	// RespondError - возврат ошибки
 	RespondError(w http.ResponseWriter, code int, err error)
 
@@ -31,8 +38,18 @@ type Router interface {
 	// /products/{key}
 	// /articles/{category}/{id:[0-9]+}").
 	AddRoute(subroute string, route string, handler http.HandlerFunc, methods ...string)
+	// AddNamedRoute - как AddRoute, но дополнительно регистрирует маршрут под именем name,
+	// чтобы потом построить по нему URL через URL/URLPath
+	AddNamedRoute(subroute, name, route string, handler http.HandlerFunc, methods ...string)
 	// AddMiddleware - добавить цепочку обработчиков на промежуточном уровне
 	AddMiddleware(subroute string, mwf ...MiddlewareFunc)
+	// AddCORS - задать политику CORS для подроутера (может быть строже, чем у корневого роутера)
+	AddCORS(subroute string, cfg CORSConfig) error
+
+	// URL - построить полный URL по имени маршрута, зарегистрированного через AddNamedRoute
+	URL(name string, pairs ...string) (*url.URL, error)
+	// URLPath - как URL, но возвращает только путь маршрута, без схемы и хоста
+	URLPath(name string, pairs ...string) (string, error)
 
 	// Возвращает переменные запроса. Переменные - это часть URL для которых были заданы маски в методе AddRoute
 	// Значения ключа - это имена переменных. Например для /products/{key} - имя ключа будет key
@@ -0,0 +1,75 @@
+package httprouter
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/n-r-w/lg"
+	"github.com/n-r-w/nerr"
+)
+
+// RecoveryOption - опция настройки Recovery middleware
+type RecoveryOption func(*recoveryConfig)
+
+type recoveryConfig struct {
+	onPanic func(r *http.Request, v any, stack []byte)
+}
+
+// WithOnPanic - хук, вызываемый сразу после перехвата паники, до ее логирования и ответа
+// клиенту. Используется, например, чтобы отправить панику во внешний трекер ошибок
+func WithOnPanic(fn func(r *http.Request, v any, stack []byte)) RecoveryOption {
+	return func(c *recoveryConfig) { c.onPanic = fn }
+}
+
+// WithoutRecovery - отключить устанавливаемый по умолчанию Recovery middleware
+func WithoutRecovery() Option {
+	return func(r *RouterData) { r.recoveryDisabled = true }
+}
+
+// Recovery - middleware, перехватывающее панику в обработчике (в т.ч. в самой RespondData,
+// которая паникует при записи в несовместимый с responseWriterEx http.ResponseWriter).
+// Без этого паника рвет соединение и запрос остается без записи в лог и без статуса ответа.
+// Recovery логирует значение паники и стек вызовов на уровне lg.Error и отвечает клиенту 500
+// через RespondError, чтобы AccessLog увидел итоговый статус и сделал свою обычную запись
+func (router *RouterData) Recovery(opts ...RecoveryOption) MiddlewareFunc {
+	cfg := &recoveryConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+
+				stack := debug.Stack()
+
+				if cfg.onPanic != nil {
+					cfg.onPanic(r, v, stack)
+				}
+
+				router.logger.Level(lg.Error, "panic recovered: %v, request_id: %s, path: %s\n%s",
+					v, RequestID(r), r.URL.Path, stack)
+
+				// если CacheControl успел включить буферизацию тела (см. responseWriterEx.buffer) и
+				// запаниковал внутри next.ServeHTTP, ответ RespondError ниже уйдет в этот буфер и
+				// никогда не будет отправлен клиенту - сбрасываем недописанное тело и сразу же
+				// принудительно отправляем результат, не дожидаясь flush от CacheControl
+				if rw, ok := w.(*responseWriterEx); ok && rw.buffer != nil {
+					rw.buffer.Reset()
+					router.RespondError(w, http.StatusInternalServerError, nerr.New(fmt.Sprintf("%v", v)))
+					rw.flush()
+					return
+				}
+
+				router.RespondError(w, http.StatusInternalServerError, nerr.New(fmt.Sprintf("%v", v)))
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package httprouter
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n-r-w/lg"
+)
+
+// stubLogger - минимальная реализация lg.Logger для тестов, ничего никуда не пишет
+type stubLogger struct{}
+
+func (stubLogger) Level(level lg.Level, format string, args ...interface{}) {}
+func (stubLogger) Error(format string, args ...interface{})                 {}
+func (stubLogger) Err(err error)                                            {}
+
+func TestRecoveryRecoversPanic(t *testing.T) {
+	router := &RouterData{logger: stubLogger{}}
+
+	handler := router.Recovery()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	rw := &responseWriterEx{ResponseWriter: rec, code: http.StatusOK}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(rw, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if rec.Body.Len() == 0 {
+		t.Error("response body should not be empty after a recovered panic")
+	}
+}
+
+// TestRecoveryFlushesBufferedResponse - chunk0-8: если CacheControl успел включить буферизацию
+// тела (ETag), а обработчик запаниковал, Recovery должен сбросить буфер и отправить 500 клиенту,
+// а не оставить ответ недописанным в буфере
+func TestRecoveryFlushesBufferedResponse(t *testing.T) {
+	router := &RouterData{logger: stubLogger{}}
+
+	handler := router.Recovery()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial body before panic"))
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	rw := &responseWriterEx{ResponseWriter: rec, code: http.StatusOK, buffer: &bytes.Buffer{}}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(rw, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if rec.Body.Len() == 0 {
+		t.Error("buffered response should have been flushed to the client after the panic")
+	}
+	if bytes.Contains(rec.Body.Bytes(), []byte("partial body before panic")) {
+		t.Error("partial pre-panic body should have been discarded, not flushed")
+	}
+}
+
+func TestRecoveryNoPanicPassesThrough(t *testing.T) {
+	router := &RouterData{logger: stubLogger{}}
+
+	handler := router.Recovery()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rec := httptest.NewRecorder()
+	rw := &responseWriterEx{ResponseWriter: rec, code: http.StatusOK}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(rw, req)
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+}
+
+func TestRecoveryWithOnPanicHook(t *testing.T) {
+	router := &RouterData{logger: stubLogger{}}
+
+	var captured any
+	handler := router.Recovery(WithOnPanic(func(r *http.Request, v any, stack []byte) {
+		captured = v
+	}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	rw := &responseWriterEx{ResponseWriter: rec, code: http.StatusOK}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(rw, req)
+
+	if captured != "boom" {
+		t.Errorf("onPanic hook captured %v, want %q", captured, "boom")
+	}
+}
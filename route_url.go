@@ -0,0 +1,86 @@
+package httprouter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/n-r-w/nerr"
+)
+
+// routeVarPattern - вычленяет имена переменных из шаблона маршрута gorilla/mux: {var} или {var:regex}
+var routeVarPattern = regexp.MustCompile(`\{([a-zA-Z0-9_]+)(?::[^}]*)?\}`)
+
+// namedRoute - маршрут, зарегистрированный через AddNamedRoute, и набор имен его переменных,
+// нужный чтобы проверять pairs, переданные в URL/URLPath, до обращения к gorilla/mux
+type namedRoute struct {
+	route *mux.Route
+	vars  map[string]struct{}
+}
+
+// RouteVarsError - в URL/URLPath не хватает значений для части переменных маршрута
+type RouteVarsError struct {
+	Name    string
+	Missing []string
+}
+
+func (e *RouteVarsError) Error() string {
+	return fmt.Sprintf("route %q: missing variables: %s", e.Name, strings.Join(e.Missing, ", "))
+}
+
+// URL - построить полный URL по имени маршрута, зарегистрированного через AddNamedRoute.
+// pairs задаются так же, как и в gorilla/mux: ключ, значение, ключ, значение, ...
+func (router *RouterData) URL(name string, pairs ...string) (*url.URL, error) {
+	nr, err := router.resolveNamedRoute(name, pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := nr.route.URL(pairs...)
+	if err != nil {
+		return nil, nerr.New(err)
+	}
+	return u, nil
+}
+
+// URLPath - как URL, но возвращает только путь маршрута, без схемы и хоста
+func (router *RouterData) URLPath(name string, pairs ...string) (string, error) {
+	nr, err := router.resolveNamedRoute(name, pairs)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := nr.route.URLPath(pairs...)
+	if err != nil {
+		return "", nerr.New(err)
+	}
+	return u.Path, nil
+}
+
+// resolveNamedRoute - найти маршрут по имени и убедиться, что pairs покрывают все его переменные
+func (router *RouterData) resolveNamedRoute(name string, pairs []string) (*namedRoute, error) {
+	nr, ok := router.namedRoutes[name]
+	if !ok {
+		return nil, nerr.New(fmt.Sprintf("route %q is not registered", name))
+	}
+
+	supplied := make(map[string]struct{}, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		supplied[pairs[i]] = struct{}{}
+	}
+
+	var missing []string
+	for v := range nr.vars {
+		if _, ok := supplied[v]; !ok {
+			missing = append(missing, v)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, &RouteVarsError{Name: name, Missing: missing}
+	}
+
+	return nr, nil
+}
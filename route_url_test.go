@@ -0,0 +1,90 @@
+package httprouter
+
+import "testing"
+
+func newNamedRouteTestRouter(name string, vars ...string) *RouterData {
+	varSet := make(map[string]struct{}, len(vars))
+	for _, v := range vars {
+		varSet[v] = struct{}{}
+	}
+
+	return &RouterData{
+		namedRoutes: map[string]*namedRoute{
+			name: {vars: varSet},
+		},
+	}
+}
+
+func TestResolveNamedRouteUnknownName(t *testing.T) {
+	router := newNamedRouteTestRouter("products", "id")
+
+	if _, err := router.resolveNamedRoute("articles", nil); err == nil {
+		t.Fatal("resolveNamedRoute with an unregistered name should return an error")
+	}
+}
+
+func TestResolveNamedRouteMissingVars(t *testing.T) {
+	router := newNamedRouteTestRouter("products", "category", "id")
+
+	_, err := router.resolveNamedRoute("products", []string{"category", "shoes"})
+	if err == nil {
+		t.Fatal("resolveNamedRoute with a missing variable should return an error")
+	}
+
+	varsErr, ok := err.(*RouteVarsError)
+	if !ok {
+		t.Fatalf("resolveNamedRoute error type = %T, want *RouteVarsError", err)
+	}
+	if varsErr.Name != "products" {
+		t.Errorf("RouteVarsError.Name = %q, want %q", varsErr.Name, "products")
+	}
+	if len(varsErr.Missing) != 1 || varsErr.Missing[0] != "id" {
+		t.Errorf("RouteVarsError.Missing = %v, want [id]", varsErr.Missing)
+	}
+}
+
+func TestResolveNamedRouteAllVarsSupplied(t *testing.T) {
+	router := newNamedRouteTestRouter("products", "category", "id")
+
+	nr, err := router.resolveNamedRoute("products", []string{"category", "shoes", "id", "42"})
+	if err != nil {
+		t.Fatalf("resolveNamedRoute() error = %v", err)
+	}
+	if nr == nil {
+		t.Fatal("resolveNamedRoute() returned nil namedRoute with no error")
+	}
+}
+
+func TestRouteVarPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		route    string
+		wantVars []string
+	}{
+		{"no vars", "/products", nil},
+		{"single var", "/products/{id}", []string{"id"}},
+		{"var with regex", "/articles/{category}/{id:[0-9]+}", []string{"category", "id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := routeVarPattern.FindAllStringSubmatch(tt.route, -1)
+			if len(matches) != len(tt.wantVars) {
+				t.Fatalf("routeVarPattern matched %d vars, want %d", len(matches), len(tt.wantVars))
+			}
+			for i, m := range matches {
+				if m[1] != tt.wantVars[i] {
+					t.Errorf("var[%d] = %q, want %q", i, m[1], tt.wantVars[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRouteVarsErrorMessage(t *testing.T) {
+	err := &RouteVarsError{Name: "products", Missing: []string{"id", "category"}}
+	want := `route "products": missing variables: id, category`
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -1,23 +1,20 @@
 package httprouter
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
 	"github.com/n-r-w/eno"
 	"github.com/n-r-w/lg"
 	"github.com/n-r-w/nerr"
-	"github.com/n-r-w/tools"
 	"github.com/thanhpk/randstr"
-	"golang.org/x/exp/slices"
 )
 
 // Тип для описания ключевых значений параметров, добавляемых в контекст запроса
@@ -34,8 +31,14 @@ const (
 // Подменяет собой стандартный http.ResponseWriter и позволяет дополнительно сохранить в нем ошибку
 type responseWriterEx struct {
 	http.ResponseWriter
-	code int
-	err  error
+	code  int
+	bytes int // количество байт, записанных в тело ответа - нужно для AccessLog
+	err   error
+
+	// buffer - если не nil, Write/WriteHeader пишут сюда вместо немедленной отправки клиенту.
+	// Включается CacheControl, когда политика просит ETag - хэш нужно посчитать от всего тела
+	// до того, как заголовки и тело будут отправлены клиенту, см. flush
+	buffer *bytes.Buffer
 }
 
 func (w *responseWriterEx) WriteHeader(statusCode int) {
@@ -43,7 +46,24 @@ func (w *responseWriterEx) WriteHeader(statusCode int) {
 		panic(nerr.New("invalid status code"))
 	}
 	w.code = statusCode
-	w.ResponseWriter.WriteHeader(statusCode)
+	if w.buffer == nil {
+		w.ResponseWriter.WriteHeader(statusCode)
+	}
+}
+
+// Write - считает записанные байты, чтобы AccessLog мог отразить размер ответа, и, пока включена
+// буферизация (см. buffer), пишет в буфер вместо клиента
+func (w *responseWriterEx) Write(data []byte) (int, error) {
+	var n int
+	var err error
+	if w.buffer != nil {
+		n, err = w.buffer.Write(data)
+	} else {
+		n, err = w.ResponseWriter.Write(data)
+	}
+
+	w.bytes += n
+	return n, err
 }
 
 func (w *responseWriterEx) WriteHeaderAndData(statusCode int, data []byte) (int, error) {
@@ -51,7 +71,9 @@ func (w *responseWriterEx) WriteHeaderAndData(statusCode int, data []byte) (int,
 		panic(nerr.New("invalid status code"))
 	}
 	w.code = statusCode
-	w.ResponseWriter.WriteHeader(statusCode)
+	if w.buffer == nil {
+		w.ResponseWriter.WriteHeader(statusCode)
+	}
 
 	if data == nil {
 		return 0, nil
@@ -59,30 +81,75 @@ func (w *responseWriterEx) WriteHeaderAndData(statusCode int, data []byte) (int,
 	return w.Write(data)
 }
 
+// flush - отправляет клиенту код ответа и буферизованное тело. Вызывается CacheControl после
+// того, как решение по ETag/304 принято
+func (w *responseWriterEx) flush() {
+	w.ResponseWriter.WriteHeader(w.code)
+	if w.buffer != nil && w.buffer.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buffer.Bytes())
+	}
+}
+
 // RouterData - реализует интерфейс Router
 type RouterData struct {
 	mux          *mux.Router
-	sessionStore sessions.Store // Управление сессиями пользователей
+	sessionStore SessionStore // Управление сессиями пользователей
 	logger       lg.Logger
 
-	subrouters map[string]*mux.Router
+	subrouters  map[string]*mux.Router
+	namedRoutes map[string]*namedRoute
+
+	corsConfig          CORSConfig
+	corsSubrouteConfigs map[string]CORSConfig
+	recoveryDisabled    bool
 }
 
-func New(logger lg.Logger) *RouterData {
+func New(logger lg.Logger, opts ...Option) *RouterData {
 	r := &RouterData{
-		mux:          mux.NewRouter(),
-		sessionStore: sessions.NewCookieStore([]byte(randstr.Hex(32))),
+		mux: mux.NewRouter(),
+		// по умолчанию - cookie-хранилище со случайным ключом. Подходит только
+		// для одного процесса: ключ не переживает перезапуск и не может быть
+		// общим для нескольких реплик. Для production лучше явно задать
+		// WithSessionStore с постоянным ключом или внешним хранилищем
+		sessionStore: NewCookieSessionStore([]byte(randstr.Hex(32))),
 		logger:       logger,
 		subrouters:   make(map[string]*mux.Router),
+		namedRoutes:  make(map[string]*namedRoute),
+		// по умолчанию разрешаем запросы с любых доменов без credentials. Задать более
+		// строгую или разрешающую credentials политику - через WithCORS
+		corsConfig:          CORSConfig{AllowedOrigins: []string{"*"}},
+		corsSubrouteConfigs: make(map[string]CORSConfig),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	// WithCORS не может вернуть ошибку сама (в отличие от AddCORS) - в отличие от подроутеров,
+	// некорректную корневую политику некуда вернуть до создания RouterData с логгером. Поэтому
+	// валидируем здесь и откатываемся к разрешающей политике по умолчанию, если cfg некорректен
+	if err := validateCORSConfig(r.corsConfig); err != nil {
+		r.logger.Err(nerr.New(err))
+		r.corsConfig = CORSConfig{AllowedOrigins: []string{"*"}}
 	}
 
 	// подмешивание номера сессии
 	r.mux.Use(r.setRequestID)
-	// журналирование запросов
-	r.mux.Use(r.logRequest)
+	// структурированный access-лог (Combined/JSON), оборачивает w в responseWriterEx
+	r.mux.Use(r.AccessLog())
+
+	if !r.recoveryDisabled {
+		// перехват паники в обработчиках (в т.ч. в самом RespondData) - должен идти после
+		// AccessLog, чтобы RespondError мог писать в уже обернутый responseWriterEx, а
+		// AccessLog после возврата из next.ServeHTTP увидел выставленный статус 500
+		r.mux.Use(r.Recovery())
+	}
 
-	// разрешаем запросы к серверу c любых доменов (cross-origin resource sharing)
-	r.mux.Use(handlers.CORS(handlers.AllowedOrigins([]string{"*"})))
+	// cross-origin resource sharing - политика задается через WithCORS/AddCORS. Ставится один
+	// middleware на корневой роутер, который сам находит политику наиболее специфичного
+	// подроутера для пути запроса - см. corsDispatch о том, почему нельзя просто навесить
+	// corsMiddleware отдельно на каждый подроутер через mux.Router.Use
+	r.mux.Use(r.corsDispatch())
 
 	return r
 }
@@ -162,8 +229,10 @@ func (router *RouterData) RespondData(w http.ResponseWriter, code int, contentTy
 	}
 }
 
-// RespondCompressed Ответ на запрос со сжатием если его поддерживает клиент
-func (router *RouterData) RespondCompressed(w http.ResponseWriter, r *http.Request, code int, compType CompressionType, contentType string, data interface{}) {
+// RespondCompressed Ответ на запрос со сжатием если его поддерживает клиент.
+// compTypes - кодировки, которые сервер готов отдать для этого конкретного ответа; итоговая
+// кодировка выбирается negotiateEncoding на основе q-значений заголовка Accept-Encoding клиента
+func (router *RouterData) RespondCompressed(w http.ResponseWriter, r *http.Request, code int, compTypes []CompressionType, contentType string, data interface{}) {
 	if data == nil {
 		router.RespondData(w, code, contentType, nil)
 
@@ -175,27 +244,13 @@ func (router *RouterData) RespondCompressed(w http.ResponseWriter, r *http.Reque
 		panic("internal error")
 	}
 
-	// проверяем хочет ли клиент сжатие
-	compressionType := CompressionNo
-
-	accepted := strings.Split(r.Header.Get("Accept-Encoding"), ",")
-	if slices.Contains(accepted, "gzip") && compType == CompressionGzip {
-		compressionType = CompressionGzip
-	} else if slices.Contains(accepted, "deflate") && compType == CompressionDeflate {
-		compressionType = CompressionDeflate
-	}
-
-	if compressionType == CompressionNo {
-		router.RespondData(w, code, contentType, data)
-		return
-	}
-
-	// заполняем буфер для сжатия
+	// заполняем буфер, чтобы решить: сжимать ли его и, если да, то чем
 	var sourceData []byte
 	switch d := data.(type) {
 	case string:
-	case []byte:
 		sourceData = []byte(d)
+	case []byte:
+		sourceData = d
 	default:
 		var errJSON error
 		sourceData, errJSON = json.Marshal(data)
@@ -206,19 +261,28 @@ func (router *RouterData) RespondCompressed(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	if compressionType == CompressionGzip {
-		rw.Header().Set("Content-Encoding", "gzip")
-	} else {
-		rw.Header().Set("Content-Encoding", "deflate")
+	compressionType := CompressionNo
+	if len(sourceData) >= minCompressSize && isCompressibleContentType(contentType) {
+		compressionType = negotiateEncoding(r.Header.Get("Accept-Encoding"), compTypes)
 	}
 
-	compressedData, err := tools.CompressData(compressionType == CompressionDeflate, sourceData)
+	if compressionType == CompressionNo {
+		if len(contentType) > 0 {
+			rw.Header().Set("Content-Type", contentType)
+		}
+		if _, err := rw.WriteHeaderAndData(code, sourceData); err != nil {
+			router.logger.Err(nerr.New(err))
+		}
+		return
+	}
 
+	compressedData, err := compressData(compressionType, sourceData)
 	if err != nil {
-		router.RespondError(w, http.StatusInternalServerError, err)
+		router.RespondError(w, http.StatusInternalServerError, nerr.New(err))
 		return
 	}
 
+	rw.Header().Set("Content-Encoding", compressionTypeNames[compressionType])
 	rw.Header().Set("Content-Type", contentType)
 	_, _ = rw.WriteHeaderAndData(code, compressedData)
 }
@@ -235,6 +299,29 @@ func (router *RouterData) AddRoute(subroute string, route string, handler http.H
 	r.HandleFunc(route, handler).Methods(methods...)
 }
 
+// AddNamedRoute - как AddRoute, но дополнительно регистрирует маршрут под именем name.
+// Зная имя, URL/URLPath строят по нему адрес, не завязываясь на конкретный путь и не зная,
+// зарегистрирован ли маршрут на подроутере и с каким префиксом
+func (router *RouterData) AddNamedRoute(subroute, name, route string, handler http.HandlerFunc, methods ...string) {
+	var r *mux.Router
+	if len(subroute) == 0 {
+		r = router.mux
+	} else {
+		r = router.getSubrouter(subroute)
+	}
+
+	muxRoute := r.HandleFunc(route, handler).Methods(methods...).Name(name)
+
+	vars := make(map[string]struct{})
+	for _, part := range []string{subroute, route} {
+		for _, m := range routeVarPattern.FindAllStringSubmatch(part, -1) {
+			vars[m[1]] = struct{}{}
+		}
+	}
+
+	router.namedRoutes[name] = &namedRoute{route: muxRoute, vars: vars}
+}
+
 // AddMiddleware ...
 func (router *RouterData) AddMiddleware(subroute string, mwf ...MiddlewareFunc) {
 	funcs := make([]mux.MiddlewareFunc, len(mwf))
@@ -314,6 +401,12 @@ func (router *RouterData) getSubrouter(path string) *mux.Router {
 	return sr
 }
 
+// RequestID - вернуть ID запроса, проставленный в контекст setRequestID
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(ctxKeyRequestID).(string)
+	return id
+}
+
 // Добавляем к контексту уникальный ID сесии с ключом ctxKeyRequestID
 func (router *RouterData) setRequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -323,46 +416,3 @@ func (router *RouterData) setRequestID(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyRequestID, id)))
 	})
 }
-
-// Выводим запросы в лог
-func (router *RouterData) logRequest(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		rw := &responseWriterEx{
-			ResponseWriter: w,
-			code:           http.StatusOK,
-			err:            nil,
-		}
-
-		// вызываем обработчик нижнего уровня
-		next.ServeHTTP(rw, r)
-
-		// выводим в журнал результат
-		var level lg.Level
-		switch {
-		case rw.code >= http.StatusInternalServerError:
-			level = lg.Error
-		case rw.code >= http.StatusBadRequest:
-			level = lg.Warn
-		default:
-			level = lg.Info
-		}
-
-		var errorText string
-		if rw.err != nil {
-			errorText = rw.err.Error()
-			errorText = strings.ReplaceAll(errorText, `"`, "")
-		} else {
-			errorText = "-"
-		}
-
-		if level == lg.Error || level == lg.Warn {
-			router.logger.Level(level, "addr: %s, completed with %d %s in %v, %s",
-				r.RemoteAddr,
-				rw.code,
-				http.StatusText(rw.code),
-				time.Since(start),
-				errorText)
-		}
-	})
-}
@@ -0,0 +1,48 @@
+package httprouter
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+)
+
+// SessionStore - интерфейс хранилища пользовательских сессий.
+// Позволяет подключать разные бэкенды (cookie, Redis, файловая система и т.д.)
+// вместо жестко заданного sessions.NewCookieStore со случайным ключом, из-за
+// которого сессии становились недействительными при каждом перезапуске и были
+// уникальны для каждой реплики сервиса. Сигнатура методов повторяет
+// sessions.Store, дополнительно добавлен Delete для явного удаления сессии
+type SessionStore interface {
+	New(r *http.Request, name string) (*sessions.Session, error)
+	Get(r *http.Request, name string) (*sessions.Session, error)
+	Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error
+	Delete(r *http.Request, w http.ResponseWriter, session *sessions.Session) error
+}
+
+// Option - опция настройки RouterData, применяемая в New
+type Option func(*RouterData)
+
+// WithSessionStore - задать бэкенд хранения сессий вместо используемого по
+// умолчанию cookie-хранилища со случайным ключом генерируемым при старте
+func WithSessionStore(store SessionStore) Option {
+	return func(r *RouterData) {
+		r.sessionStore = store
+	}
+}
+
+// gobEncode - сериализует значения сессии для хранения вне процесса (Redis,
+// файл, зашифрованный cookie)
+func gobEncode(values map[interface{}]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gobDecode - обратная операция к gobEncode
+func gobDecode(data []byte, values *map[interface{}]interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(values)
+}
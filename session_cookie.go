@@ -0,0 +1,41 @@
+package httprouter
+
+import (
+	"net/http"
+
+	"github.com/gorilla/sessions"
+)
+
+// CookieSessionStore - хранилище сессий на основе подписанных/зашифрованных
+// cookie (обертка над sessions.CookieStore). В отличие от New, где ключ
+// генерируется случайно при старте процесса, здесь ключи передаются извне -
+// это позволяет сохранять их между перезапусками и использовать один и тот же
+// ключ на всех репликах сервиса
+type CookieSessionStore struct {
+	store *sessions.CookieStore
+}
+
+// NewCookieSessionStore - создать хранилище сессий на основе cookie.
+// keyPairs задаются в том же порядке, что и для sessions.NewCookieStore:
+// ключ аутентификации (обязателен) и, опционально, ключ шифрования
+func NewCookieSessionStore(keyPairs ...[]byte) *CookieSessionStore {
+	return &CookieSessionStore{store: sessions.NewCookieStore(keyPairs...)}
+}
+
+func (s *CookieSessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	return s.store.New(r, name)
+}
+
+func (s *CookieSessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return s.store.Get(r, name)
+}
+
+func (s *CookieSessionStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	return s.store.Save(r, w, session)
+}
+
+// Delete - удалить сессию, выставив отрицательный MaxAge, как это принято в gorilla/sessions
+func (s *CookieSessionStore) Delete(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	session.Options.MaxAge = -1
+	return s.store.Save(r, w, session)
+}
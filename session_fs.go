@@ -0,0 +1,43 @@
+package httprouter
+
+import (
+	"net/http"
+
+	"github.com/gorilla/sessions"
+)
+
+// FilesystemSessionStore - хранилище сессий на файловой системе (обертка над
+// sessions.FilesystemStore). В cookie хранится только подписанный
+// идентификатор сессии, сами данные лежат в отдельном файле на диске - в
+// отличие от CookieSessionStore подходит для сессий большого размера, но, как
+// и он, требует общего диска при работе нескольких реплик
+type FilesystemSessionStore struct {
+	store *sessions.FilesystemStore
+}
+
+// NewFilesystemSessionStore - создать файловое хранилище сессий.
+// dir - каталог для хранения файлов сессий (при пустой строке используется
+// os.TempDir()), keyPairs - ключи аутентификации/шифрования cookie с
+// идентификатором сессии, как и для NewCookieSessionStore
+func NewFilesystemSessionStore(dir string, keyPairs ...[]byte) *FilesystemSessionStore {
+	return &FilesystemSessionStore{store: sessions.NewFilesystemStore(dir, keyPairs...)}
+}
+
+func (s *FilesystemSessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	return s.store.New(r, name)
+}
+
+func (s *FilesystemSessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return s.store.Get(r, name)
+}
+
+func (s *FilesystemSessionStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	return s.store.Save(r, w, session)
+}
+
+// Delete - удалить сессию, выставив отрицательный MaxAge: FilesystemStore сам
+// удаляет соответствующий файл при сохранении такой сессии
+func (s *FilesystemSessionStore) Delete(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	session.Options.MaxAge = -1
+	return s.store.Save(r, w, session)
+}
@@ -0,0 +1,115 @@
+package httprouter
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/n-r-w/nerr"
+	"github.com/redis/go-redis/v9"
+	"github.com/thanhpk/randstr"
+)
+
+// RedisSessionStore - хранилище сессий в Redis. В cookie хранится только
+// случайный идентификатор сессии (подписанный securecookie, чтобы его нельзя
+// было подделать), а сами значения сессии сериализуются (gob) и кладутся в
+// Redis под ключом prefix+id с TTL, равным времени жизни сессии - это снимает
+// ограничение на размер сессии и позволяет шарить сессии между репликами
+type RedisSessionStore struct {
+	client *redis.Client
+	codecs []securecookie.Codec
+	prefix string
+	opts   *sessions.Options
+}
+
+// NewRedisSessionStore - создать хранилище сессий в Redis.
+// prefix добавляется к идентификатору сессии при формировании ключа в Redis,
+// keyPairs используются для подписи cookie с идентификатором сессии
+func NewRedisSessionStore(client *redis.Client, prefix string, keyPairs ...[]byte) *RedisSessionStore {
+	return &RedisSessionStore{
+		client: client,
+		codecs: securecookie.CodecsFromPairs(keyPairs...),
+		prefix: prefix,
+		opts:   &sessions.Options{Path: "/", MaxAge: 86400 * 30},
+	}
+}
+
+func (s *RedisSessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.opts
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	var sessionID string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &sessionID, s.codecs...); err != nil {
+		return session, nil
+	}
+
+	data, err := s.client.Get(r.Context(), s.key(sessionID)).Bytes()
+	if err != nil {
+		return session, nil
+	}
+
+	if err := gobDecode(data, &session.Values); err != nil {
+		return session, nil
+	}
+
+	session.ID = sessionID
+	session.IsNew = false
+	return session, nil
+}
+
+func (s *RedisSessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *RedisSessionStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		return s.Delete(r, w, session)
+	}
+
+	if session.ID == "" {
+		session.ID = randstr.Hex(32)
+	}
+
+	data, err := gobEncode(session.Values)
+	if err != nil {
+		return nerr.New(err)
+	}
+
+	ttl := time.Duration(session.Options.MaxAge) * time.Second
+	if err := s.client.Set(r.Context(), s.key(session.ID), data, ttl).Err(); err != nil {
+		return nerr.New(err)
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return nerr.New(err)
+	}
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Delete - удалить сессию из Redis и затереть cookie
+func (s *RedisSessionStore) Delete(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.ID != "" {
+		if err := s.client.Del(r.Context(), s.key(session.ID)).Err(); err != nil {
+			return nerr.New(err)
+		}
+	}
+
+	session.Options.MaxAge = -1
+	http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+	return nil
+}
+
+func (s *RedisSessionStore) key(sessionID string) string {
+	return s.prefix + sessionID
+}
@@ -0,0 +1,166 @@
+package httprouter
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+	"github.com/n-r-w/nerr"
+)
+
+// SecretBoxKey - ключ шифрования AES-GCM, используемый SecretBoxSessionStore,
+// с идентификатором для поддержки ротации. ID кладется в cookie одним байтом,
+// поэтому должен укладываться в диапазон 0-255
+type SecretBoxKey struct {
+	ID  int
+	Key []byte // 16, 24 или 32 байта - AES-128/192/256
+}
+
+// SecretBoxSessionStore - хранилище сессий, которое шифрует все значения
+// сессии AES-GCM и хранит их целиком в cookie (на сервере данные не
+// сохраняются). Перед шифротекстом кладется ID ключа, которым он зашифрован -
+// это позволяет при ротации добавить новый ключ для шифрования новых сессий,
+// сохранив старые ключи в списке только для расшифровки уже выданных cookie,
+// пока они не истекут
+type SecretBoxSessionStore struct {
+	keys       map[int]cipher.AEAD
+	currentKey int
+	opts       *sessions.Options
+}
+
+// NewSecretBoxSessionStore - создать хранилище. currentKeyID задает ключ
+// (из keys), которым будут шифроваться новые сессии, остальные ключи
+// используются только для расшифровки
+func NewSecretBoxSessionStore(currentKeyID int, keys ...SecretBoxKey) (*SecretBoxSessionStore, error) {
+	s := &SecretBoxSessionStore{
+		keys:       make(map[int]cipher.AEAD, len(keys)),
+		currentKey: currentKeyID,
+		opts:       &sessions.Options{Path: "/", MaxAge: 86400 * 30},
+	}
+
+	for _, k := range keys {
+		if k.ID < 0 || k.ID > 255 {
+			return nil, nerr.New("secret box: key id must be in range 0-255")
+		}
+
+		block, err := aes.NewCipher(k.Key)
+		if err != nil {
+			return nil, nerr.New(err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, nerr.New(err)
+		}
+
+		s.keys[k.ID] = aead
+	}
+
+	if _, ok := s.keys[currentKeyID]; !ok {
+		return nil, nerr.New("secret box: current key id is not among the supplied keys")
+	}
+
+	return s, nil
+}
+
+func (s *SecretBoxSessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.opts
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	if err := s.decode(cookie.Value, session); err != nil {
+		return session, nil
+	}
+
+	session.IsNew = false
+	return session, nil
+}
+
+func (s *SecretBoxSessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *SecretBoxSessionStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	encoded, err := s.encode(session)
+	if err != nil {
+		return nerr.New(err)
+	}
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Delete - затереть cookie, выставив отрицательный MaxAge
+func (s *SecretBoxSessionStore) Delete(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	session.Options.MaxAge = -1
+	return s.Save(r, w, session)
+}
+
+// encode - сериализует Values, шифрует текущим ключом и добавляет спереди
+// его ID, чтобы decode знал, каким ключом расшифровывать
+func (s *SecretBoxSessionStore) encode(session *sessions.Session) (string, error) {
+	aead := s.keys[s.currentKey]
+
+	plain, err := gobEncode(session.Values)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, plain, nil)
+
+	payload := make([]byte, 0, len(sealed)+1)
+	payload = append(payload, byte(s.currentKey))
+	payload = append(payload, sealed...)
+
+	return base64.URLEncoding.EncodeToString(payload), nil
+}
+
+// decode - читает ID ключа из первого байта, ищет его среди всех известных
+// ключей (включая выведенные из использования для шифрования новых сессий)
+// и расшифровывает оставшиеся данные
+func (s *SecretBoxSessionStore) decode(value string, session *sessions.Session) error {
+	payload, err := base64.URLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	if len(payload) < 1 {
+		return nerr.New("secret box: empty payload")
+	}
+
+	aead, ok := s.keys[int(payload[0])]
+	if !ok {
+		return nerr.New("secret box: unknown key id")
+	}
+
+	sealed := payload[1:]
+	if len(sealed) < aead.NonceSize() {
+		return nerr.New("secret box: malformed payload")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return err
+	}
+
+	return gobDecode(plain, &session.Values)
+}
@@ -0,0 +1,120 @@
+package httprouter
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+func testSecretBoxKey(id int, fill byte) SecretBoxKey {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = fill
+	}
+	return SecretBoxKey{ID: id, Key: key}
+}
+
+func TestSecretBoxEncodeDecodeRoundTrip(t *testing.T) {
+	store, err := NewSecretBoxSessionStore(1, testSecretBoxKey(1, 0x11))
+	if err != nil {
+		t.Fatalf("NewSecretBoxSessionStore() error = %v", err)
+	}
+
+	session := sessions.NewSession(store, "s")
+	session.Values["user"] = "alice"
+
+	encoded, err := store.encode(session)
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+
+	decoded := sessions.NewSession(store, "s")
+	if err := store.decode(encoded, decoded); err != nil {
+		t.Fatalf("decode() error = %v", err)
+	}
+
+	if decoded.Values["user"] != "alice" {
+		t.Errorf("decoded user = %v, want alice", decoded.Values["user"])
+	}
+}
+
+// TestSecretBoxKeyRotation проверяет, что после ротации currentKeyID старые cookie,
+// зашифрованные предыдущим ключом, все еще расшифровываются (ключ остается в keys для чтения)
+func TestSecretBoxKeyRotation(t *testing.T) {
+	oldStore, err := NewSecretBoxSessionStore(1, testSecretBoxKey(1, 0x11), testSecretBoxKey(2, 0x22))
+	if err != nil {
+		t.Fatalf("NewSecretBoxSessionStore() error = %v", err)
+	}
+
+	session := sessions.NewSession(oldStore, "s")
+	session.Values["user"] = "bob"
+
+	encoded, err := oldStore.encode(session)
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+
+	// ротация: новый currentKeyID=2, но ключ 1 остается в списке для расшифровки старых cookie
+	newStore, err := NewSecretBoxSessionStore(2, testSecretBoxKey(1, 0x11), testSecretBoxKey(2, 0x22))
+	if err != nil {
+		t.Fatalf("NewSecretBoxSessionStore() after rotation error = %v", err)
+	}
+
+	decoded := sessions.NewSession(newStore, "s")
+	if err := newStore.decode(encoded, decoded); err != nil {
+		t.Fatalf("decode() after rotation error = %v", err)
+	}
+	if decoded.Values["user"] != "bob" {
+		t.Errorf("decoded user after rotation = %v, want bob", decoded.Values["user"])
+	}
+
+	// новые сессии шифруются уже новым ключом
+	reencoded, err := newStore.encode(session)
+	if err != nil {
+		t.Fatalf("encode() with rotated key error = %v", err)
+	}
+	payload, err := base64.URLEncoding.DecodeString(reencoded)
+	if err != nil {
+		t.Fatalf("decoding re-encoded cookie value error = %v", err)
+	}
+	if payload[0] != 2 {
+		t.Errorf("encode() after rotation used key id %d, want 2", payload[0])
+	}
+}
+
+func TestSecretBoxKeyIDOutOfRange(t *testing.T) {
+	tests := []struct {
+		name string
+		id   int
+	}{
+		{"negative", -1},
+		{"above byte range", 256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSecretBoxSessionStore(tt.id, testSecretBoxKey(tt.id, 0x11)); err == nil {
+				t.Errorf("NewSecretBoxSessionStore(%d, ...) should have failed validation", tt.id)
+			}
+		})
+	}
+}
+
+func TestSecretBoxNewWithoutCookie(t *testing.T) {
+	store, err := NewSecretBoxSessionStore(1, testSecretBoxKey(1, 0x11))
+	if err != nil {
+		t.Fatalf("NewSecretBoxSessionStore() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	session, err := store.New(req, "s")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !session.IsNew {
+		t.Error("session with no cookie should be IsNew")
+	}
+}